@@ -7,12 +7,21 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -34,17 +43,149 @@ type RedashQueryListResponse struct {
 
 // Struct for Redash query detail (simplified, add more fields as needed)
 type RedashQueryDetail struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Query string `json:"query"`
+	ID                int                `json:"id"`
+	Name              string             `json:"name"`
+	Query             string             `json:"query"`
+	LatestQueryDataID *int               `json:"latest_query_data_id"`
+	Options           RedashQueryOptions `json:"options"`
 	// Add more fields as needed
 }
 
+// RedashQueryOptions holds the subset of a query's "options" blob that
+// this server understands.
+type RedashQueryOptions struct {
+	Parameters []RedashQueryParameter `json:"parameters"`
+}
+
+// RedashQueryParameter describes one entry of a query's parameter
+// schema, as returned under "options.parameters".
+type RedashQueryParameter struct {
+	Name   string      `json:"name"`
+	Title  string      `json:"title"`
+	Type   string      `json:"type"` // text, number, date, datetime-local, date-range, datetime-range, enum, or query
+	Global bool        `json:"global"`
+	Value  interface{} `json:"value,omitempty"`
+	// EnumOptions lists the valid values for a "enum" parameter,
+	// newline-separated, mirroring Redash's own representation.
+	EnumOptions string `json:"enumOptions,omitempty"`
+	// QueryID is the id of the query backing a "query" (dropdown)
+	// parameter's options.
+	QueryID int `json:"queryId,omitempty"`
+}
+
 // Redash API client struct
 
+// RedashClient is the shared client for talking to a Redash instance.
+// All requests flow through do, which applies retry, rate-limiting, and
+// error wrapping uniformly.
 type RedashClient struct {
-	BaseURL string
-	APIKey  string
+	BaseURL     string
+	Credentials CredentialProvider
+
+	httpClient *http.Client
+	limiter    *rateLimiter
+	maxRetries int
+
+	schemaCacheTTL time.Duration
+}
+
+// CredentialProvider resolves the Authorization header a RedashClient
+// should send for a given request, e.g. "Key abc123" for a Redash API
+// key or "Bearer eyJ..." for a forwarded OIDC token. Implementations may
+// consult ctx, which carries the MCP request's own context.
+type CredentialProvider interface {
+	AuthHeader(ctx context.Context) (string, error)
+}
+
+// staticAuthHeader is a CredentialProvider that always returns the same
+// Authorization header value, used for a single env-configured API key
+// or a single per-request token already resolved up front.
+type staticAuthHeader string
+
+func (h staticAuthHeader) AuthHeader(context.Context) (string, error) {
+	if h == "" {
+		return "", fmt.Errorf("no Redash credentials configured")
+	}
+	return string(h), nil
+}
+
+// APIKeyCredential builds a CredentialProvider for a Redash API key,
+// such as the process-wide key from REDASH_API_KEY or a per-query key
+// issued by Redash for embedding.
+func APIKeyCredential(apiKey string) CredentialProvider {
+	if apiKey == "" {
+		return staticAuthHeader("")
+	}
+	return staticAuthHeader("Key " + apiKey)
+}
+
+// BearerTokenCredential builds a CredentialProvider for an OIDC bearer
+// token forwarded from the MCP caller to Redash.
+func BearerTokenCredential(token string) CredentialProvider {
+	return staticAuthHeader("Bearer " + token)
+}
+
+// AuthHeaderCredential builds a CredentialProvider from an
+// already-formed Authorization header value (e.g. one copied verbatim
+// from an incoming HTTP request), whatever scheme it uses.
+func AuthHeaderCredential(header string) CredentialProvider {
+	return staticAuthHeader(header)
+}
+
+// defaultHTTPClient is used when no *http.Client is supplied, with
+// connection pooling and a sane overall request timeout.
+var defaultHTTPClient = &http.Client{
+	Timeout: 30 * time.Second,
+	Transport: &http.Transport{
+		MaxIdleConns:        50,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+	},
+}
+
+// defaultMaxRetries bounds how many times do retries an idempotent
+// request that fails with a 429 or 5xx response.
+const defaultMaxRetries = 3
+
+// defaultSchemaCacheTTL bounds how long a data source's schema is cached
+// before GetDataSourceSchema refetches it.
+const defaultSchemaCacheTTL = 5 * time.Minute
+
+// sharedLimiter throttles all outgoing Redash requests regardless of which
+// RedashClient issues them, since per-request clients (one per MCP call in
+// -http mode) still share the same downstream Redash instance.
+var sharedLimiter = newRateLimiter(5, 5) // 5 requests/sec, burst of 5
+
+// schemaCacheKey identifies one cached schema lookup. credentialHash scopes
+// the entry to the credentials that fetched it, so one tenant's cached
+// schema in -http multi-tenant mode is never served to a client
+// authenticated with different credentials, even for the same data source.
+type schemaCacheKey struct {
+	dataSourceID   int
+	credentialHash string
+}
+
+// schemaCacheMu guards schemaCache, the package-level schema cache shared
+// across every RedashClient so that per-request clients in -http mode don't
+// each pay for their own cold cache.
+var (
+	schemaCacheMu sync.Mutex
+	schemaCache   = make(map[schemaCacheKey]schemaCacheEntry)
+)
+
+// NewRedashClient builds a RedashClient for the given Redash instance and
+// credentials. Use this directly when credentials are resolved per request
+// (e.g. a bearer token forwarded from an incoming HTTP call); use
+// NewRedashClientFromEnv for the single process-wide API key case.
+func NewRedashClient(baseURL string, creds CredentialProvider) *RedashClient {
+	return &RedashClient{
+		BaseURL:        baseURL,
+		Credentials:    creds,
+		httpClient:     defaultHTTPClient,
+		limiter:        sharedLimiter,
+		maxRetries:     defaultMaxRetries,
+		schemaCacheTTL: defaultSchemaCacheTTL,
+	}
 }
 
 func NewRedashClientFromEnv() (*RedashClient, error) {
@@ -53,26 +194,212 @@ func NewRedashClientFromEnv() (*RedashClient, error) {
 	if baseURL == "" || apiKey == "" {
 		return nil, fmt.Errorf("REDASH_BASE_URL or REDASH_API_KEY is not set")
 	}
-	return &RedashClient{BaseURL: baseURL, APIKey: apiKey}, nil
+	return NewRedashClient(baseURL, APIKeyCredential(apiKey)), nil
 }
 
-// RedashClient: GET request with context
-func (c *RedashClient) get(ctx context.Context, endpoint string, out interface{}) error {
-	url := c.BaseURL + endpoint
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return err
+// RedashError wraps a failed Redash API response so callers can
+// differentiate, e.g., a 404 from a 403 without parsing error strings.
+type RedashError struct {
+	Endpoint   string
+	Method     string
+	StatusCode int
+	Body       string // a short snippet of the response body, for diagnostics
+	RequestID  string // Redash's X-Request-Id response header, when present
+}
+
+func (e *RedashError) Error() string {
+	msg := fmt.Sprintf("Redash API request failed: %s %s returned %d", e.Method, e.Endpoint, e.StatusCode)
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request id %s)", e.RequestID)
 	}
-	req.Header.Set("Authorization", "Key "+c.APIKey)
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// redashErrMessage renders err for an LLM-facing tool result, adding a
+// Redash-specific hint when the error came back as a RedashError.
+func redashErrMessage(prefix string, err error) string {
+	var rerr *RedashError
+	if errors.As(err, &rerr) {
+		switch rerr.StatusCode {
+		case http.StatusNotFound:
+			return fmt.Sprintf("%s: not found (%s %s)", prefix, rerr.Method, rerr.Endpoint)
+		case http.StatusForbidden:
+			return fmt.Sprintf("%s: forbidden — check the Redash API key's permissions (%s %s)", prefix, rerr.Method, rerr.Endpoint)
+		default:
+			return fmt.Sprintf("%s: %v", prefix, rerr)
+		}
+	}
+	return fmt.Sprintf("%s: %v", prefix, err)
+}
+
+// bodySnippetLimit caps how much of a failed response body is kept in a
+// RedashError, so a large HTML error page doesn't blow up tool output.
+const bodySnippetLimit = 500
+
+// isIdempotentMethod reports whether method is safe to retry automatically.
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// do sends a Redash API request, applying the client's rate limiter and,
+// for idempotent methods, retrying 429/5xx responses with exponential
+// backoff honoring any Retry-After header. body is marshaled as JSON
+// when non-nil; out, if non-nil, receives the decoded JSON response.
+func (c *RedashClient) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var rawBody []byte
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rawBody = b
+	}
+
+	endpoint := c.BaseURL + path
+	backoff := 500 * time.Millisecond
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		var bodyReader io.Reader
+		if rawBody != nil {
+			bodyReader = bytes.NewReader(rawBody)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, bodyReader)
+		if err != nil {
+			return err
+		}
+		authHeader, err := c.Credentials.AuthHeader(ctx)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", authHeader)
+		if rawBody != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if !isIdempotentMethod(method) {
+				return err
+			}
+			lastErr = err
+		} else {
+			respBody, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if readErr != nil {
+				return readErr
+			}
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				if out == nil || len(respBody) == 0 {
+					return nil
+				}
+				return json.Unmarshal(respBody, out)
+			}
+			lastErr = &RedashError{
+				Endpoint:   path,
+				Method:     method,
+				StatusCode: resp.StatusCode,
+				Body:       snippet(respBody, bodySnippetLimit),
+				RequestID:  resp.Header.Get("X-Request-Id"),
+			}
+			if !isIdempotentMethod(method) || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500) {
+				return lastErr
+			}
+			if wait := retryAfterDuration(resp.Header.Get("Retry-After")); wait > 0 {
+				backoff = wait
+			}
+		}
+
+		if attempt >= c.maxRetries {
+			return lastErr
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Redash API request failed: %s", resp.Status)
+}
+
+// retryAfterDuration parses a Retry-After header value (seconds only,
+// as Redash sends) and returns 0 if it is absent or malformed.
+func retryAfterDuration(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	secs, err := strconv.Atoi(header)
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// snippet trims whitespace from b and, if more than n bytes remain, cuts
+// it to its first n bytes followed by "...", for embedding in error
+// messages.
+func snippet(b []byte, n int) string {
+	s := strings.TrimSpace(string(b))
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
+
+// rateLimiter is a minimal token-bucket limiter used to avoid hammering
+// the Redash instance with concurrent MCP tool calls.
+type rateLimiter struct {
+	mu         sync.Mutex
+	tokens     float64
+	ratePerSec float64
+	burst      float64
+	last       time.Time
+}
+
+func newRateLimiter(ratePerSec, burst float64) *rateLimiter {
+	return &rateLimiter{tokens: burst, ratePerSec: ratePerSec, burst: burst, last: time.Now()}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.ratePerSec
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.ratePerSec * float64(time.Second))
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
 	}
-	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// RedashClient: GET request with context
+func (c *RedashClient) get(ctx context.Context, endpoint string, out interface{}) error {
+	return c.do(ctx, http.MethodGet, endpoint, nil, out)
 }
 
 // Fetch all queries
@@ -110,73 +437,378 @@ type CreateQueryResult struct {
 
 // RedashClient: create a new query
 func (c *RedashClient) CreateQuery(ctx context.Context, args CreateQueryArgs) (*RedashQueryDetail, error) {
-	endpoint := c.BaseURL + "/api/queries"
-	body, err := json.Marshal(map[string]interface{}{
+	body := map[string]interface{}{
 		"name":           args.Name,
 		"query":          args.Query,
 		"data_source_id": args.DataSourceID,
-	})
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
-	if err != nil {
-		return nil, err
 	}
-	req.Header.Set("Authorization", "Key "+c.APIKey)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
+	var result RedashQueryDetail
+	if err := c.do(ctx, http.MethodPost, "/api/queries", body, &result); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("Redash API request failed: %s", resp.Status)
+	return &result, nil
+}
+
+// RedashResultColumn describes one column of a query result, as returned
+// under the "data.columns" key of a Redash query result payload.
+type RedashResultColumn struct {
+	Name         string `json:"name"`
+	FriendlyName string `json:"friendly_name"`
+	Type         string `json:"type"`
+}
+
+// RedashQueryResult is a Redash query result, as returned by both
+// /api/queries/{id}/results/{result_id}.json and the "query_result" field of
+// /api/queries/{id}/results.
+type RedashQueryResult struct {
+	ID          int                   `json:"id"`
+	QueryID     int                   `json:"query_id"`
+	Data        RedashQueryResultData `json:"data"`
+	RetrievedAt time.Time             `json:"retrieved_at"`
+	Runtime     float64               `json:"runtime"`
+}
+
+type RedashQueryResultData struct {
+	Columns []RedashResultColumn     `json:"columns"`
+	Rows    []map[string]interface{} `json:"rows"`
+}
+
+// Redash job status codes, as returned by /api/jobs/{job_id}.
+const (
+	jobStatusPending   = 1
+	jobStatusStarted   = 2
+	jobStatusSuccess   = 3
+	jobStatusFailure   = 4
+	jobStatusCancelled = 5
+)
+
+// RedashJob is the job resource Redash returns while a query result is
+// still being computed.
+type RedashJob struct {
+	ID            string `json:"id"`
+	Status        int    `json:"status"`
+	Error         string `json:"error"`
+	QueryResultID int    `json:"query_result_id"`
+}
+
+// jobPollInitialInterval and jobPollMaxInterval bound the exponential
+// backoff used while waiting for a Redash job to finish.
+const (
+	jobPollInitialInterval = 500 * time.Millisecond
+	jobPollMaxInterval     = 8 * time.Second
+)
+
+// pollJob polls /api/jobs/{job_id} until the job succeeds or fails,
+// backing off exponentially between polls and honoring ctx cancellation.
+func (c *RedashClient) pollJob(ctx context.Context, jobID string) (*RedashJob, error) {
+	interval := jobPollInitialInterval
+	for {
+		var wrapper struct {
+			Job RedashJob `json:"job"`
+		}
+		if err := c.get(ctx, fmt.Sprintf("/api/jobs/%s", jobID), &wrapper); err != nil {
+			return nil, err
+		}
+		switch wrapper.Job.Status {
+		case jobStatusSuccess:
+			return &wrapper.Job, nil
+		case jobStatusFailure, jobStatusCancelled:
+			return nil, fmt.Errorf("Redash job %s failed: %s", jobID, wrapper.Job.Error)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+		interval *= 2
+		if interval > jobPollMaxInterval {
+			interval = jobPollMaxInterval
+		}
 	}
-	var result RedashQueryDetail
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+}
+
+// GetQueryResultByID fetches a previously computed query result directly,
+// without triggering a new execution.
+func (c *RedashClient) GetQueryResultByID(ctx context.Context, queryID, resultID int) (*RedashQueryResult, error) {
+	var result RedashQueryResult
+	endpoint := fmt.Sprintf("/api/queries/%d/results/%d.json", queryID, resultID)
+	if err := c.get(ctx, endpoint, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
+// GetCachedResult returns a cached result for a query without executing
+// it: the result with resultID, or, if resultID is 0, the query's most
+// recent one (latest_query_data_id).
+func (c *RedashClient) GetCachedResult(ctx context.Context, queryID, resultID int) (*RedashQueryResult, error) {
+	if resultID == 0 {
+		detail, err := c.GetQueryByID(ctx, queryID)
+		if err != nil {
+			return nil, err
+		}
+		if detail.LatestQueryDataID == nil {
+			return nil, fmt.Errorf("query %d has no cached result", queryID)
+		}
+		resultID = *detail.LatestQueryDataID
+	}
+	return c.GetQueryResultByID(ctx, queryID, resultID)
+}
+
+// isResultFresh reports whether a result retrieved at retrievedAt still
+// satisfies maxAge (in seconds), mirroring Redash's own max_age semantics:
+// a negative maxAge means "always use the cache" and 0 means "never".
+func isResultFresh(retrievedAt time.Time, maxAge int) bool {
+	if maxAge < 0 {
+		return true
+	}
+	return time.Since(retrievedAt) <= time.Duration(maxAge)*time.Second
+}
+
 // Args and result for execute_query
 
 type ExecuteQueryArgs struct {
 	ID int `json:"id"`
+	// MaxAge mirrors Redash's max_age query param: a cached result no
+	// older than MaxAge seconds is reused instead of re-executing the
+	// query. 0 forces re-execution; a negative value always reuses the
+	// latest cached result when one exists. Defaults to 0.
+	MaxAge int `json:"max_age,omitempty"`
+	// Format controls how the result is rendered for the LLM: "json"
+	// (default), "csv", or "text" (a compact tabular block).
+	Format string `json:"format,omitempty"`
 }
 
 type ExecuteQueryResult struct {
-	QueryResult interface{} `json:"query_result"`
+	QueryResult *RedashQueryResult `json:"query_result"`
+}
+
+// RedashClient: execute a query and get result, reusing a fresh cached
+// result when possible and otherwise POSTing a new execution and polling
+// the resulting job to completion.
+func (c *RedashClient) ExecuteQuery(ctx context.Context, id int, maxAge int) (*RedashQueryResult, error) {
+	return c.executeQuery(ctx, id, maxAge, nil)
 }
 
-// RedashClient: execute a query and get result
-func (c *RedashClient) ExecuteQuery(ctx context.Context, id int) (interface{}, error) {
-	endpoint := fmt.Sprintf("%s/api/queries/%d/results", c.BaseURL, id)
-	body := bytes.NewReader([]byte(`{}`))
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, body)
+// ExecuteQueryWithParameters executes a parameterized query after
+// validating parameters against the query's own parameter schema and
+// coercing them to the types Redash expects.
+func (c *RedashClient) ExecuteQueryWithParameters(ctx context.Context, id int, maxAge int, parameters map[string]interface{}) (*RedashQueryResult, error) {
+	detail, err := c.GetQueryByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Authorization", "Key "+c.APIKey)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
+	coerced, err := coerceParameters(detail.Options.Parameters, parameters)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Redash API request failed: %s", resp.Status)
+	return c.executeQuery(ctx, id, maxAge, coerced)
+}
+
+// executeQuery does the actual work shared by ExecuteQuery and
+// ExecuteQueryWithParameters: when no parameters are given and maxAge is
+// non-zero, it first tries a fresh cached result, then falls back to
+// POSTing a new execution and polling the resulting job.
+func (c *RedashClient) executeQuery(ctx context.Context, id int, maxAge int, parameters map[string]interface{}) (*RedashQueryResult, error) {
+	if maxAge != 0 && len(parameters) == 0 {
+		if detail, err := c.GetQueryByID(ctx, id); err == nil && detail.LatestQueryDataID != nil {
+			if cached, err := c.GetQueryResultByID(ctx, id, *detail.LatestQueryDataID); err == nil && isResultFresh(cached.RetrievedAt, maxAge) {
+				return cached, nil
+			}
+		}
+	}
+
+	reqPayload := map[string]interface{}{"max_age": maxAge}
+	if len(parameters) > 0 {
+		reqPayload["parameters"] = parameters
+	}
+	var result struct {
+		QueryResult *RedashQueryResult `json:"query_result"`
+		Job         *RedashJob         `json:"job"`
+	}
+	path := fmt.Sprintf("/api/queries/%d/results", id)
+	if err := c.do(ctx, http.MethodPost, path, reqPayload, &result); err != nil {
+		return nil, err
+	}
+	if result.QueryResult != nil {
+		return result.QueryResult, nil
 	}
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if result.Job == nil {
+		return nil, fmt.Errorf("Redash API returned neither a query_result nor a job for query %d", id)
+	}
+	job, err := c.pollJob(ctx, result.Job.ID)
+	if err != nil {
 		return nil, err
 	}
-	// Return the query_result field if present
-	if qr, ok := result["query_result"]; ok {
-		return qr, nil
+	return c.GetQueryResultByID(ctx, id, job.QueryResultID)
+}
+
+// coerceParameters validates that every key in params names a parameter
+// in schema, then coerces each value to the Go representation Redash
+// expects on the wire (e.g. JSON numbers to Redash "number" parameters,
+// RFC3339 timestamps to Redash's "date" format).
+func coerceParameters(schema []RedashQueryParameter, params map[string]interface{}) (map[string]interface{}, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+	byName := make(map[string]RedashQueryParameter, len(schema))
+	for _, p := range schema {
+		byName[p.Name] = p
+	}
+	out := make(map[string]interface{}, len(params))
+	for name, raw := range params {
+		param, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown parameter %q for this query", name)
+		}
+		coerced, err := coerceParameterValue(param, raw)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %w", name, err)
+		}
+		out[name] = coerced
+	}
+	return out, nil
+}
+
+// redashDateFormat is the date format Redash expects for "date" and
+// "date-range" parameter values.
+const redashDateFormat = "2006-01-02"
+
+// redashDateTimeFormat is the format Redash expects for "datetime-local"
+// and "datetime-range" parameter values, which keep a time component.
+const redashDateTimeFormat = "2006-01-02 15:04:05"
+
+// coerceParameterValue coerces a single raw JSON value to the shape
+// Redash expects for the given parameter's type.
+func coerceParameterValue(param RedashQueryParameter, raw interface{}) (interface{}, error) {
+	switch param.Type {
+	case "number":
+		switch v := raw.(type) {
+		case float64:
+			return v, nil
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("want a number, got %q", v)
+			}
+			return f, nil
+		default:
+			return nil, fmt.Errorf("want a number, got %T", raw)
+		}
+	case "date":
+		return coerceDateValue(raw, redashDateFormat)
+	case "datetime-local":
+		return coerceDateValue(raw, redashDateTimeFormat)
+	case "date-range", "datetime-range":
+		layout := redashDateFormat
+		if param.Type == "datetime-range" {
+			layout = redashDateTimeFormat
+		}
+		rangeValue, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("want an object with start/end, got %T", raw)
+		}
+		start, err := coerceDateValue(rangeValue["start"], layout)
+		if err != nil {
+			return nil, fmt.Errorf("start: %w", err)
+		}
+		end, err := coerceDateValue(rangeValue["end"], layout)
+		if err != nil {
+			return nil, fmt.Errorf("end: %w", err)
+		}
+		return map[string]interface{}{"start": start, "end": end}, nil
+	case "enum":
+		v, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("want a string, got %T", raw)
+		}
+		options := strings.Split(param.EnumOptions, "\n")
+		for _, opt := range options {
+			if opt == v {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not one of the allowed values %v", v, options)
+	case "query":
+		return fmt.Sprintf("%v", raw), nil
+	default: // "text" and anything unrecognized
+		return fmt.Sprintf("%v", raw), nil
+	}
+}
+
+// coerceDateValue accepts either an RFC3339 timestamp or a string
+// already in layout, and returns it formatted in layout.
+func coerceDateValue(raw interface{}, layout string) (string, error) {
+	s, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("want a date string, got %T", raw)
+	}
+	if _, err := time.Parse(layout, s); err == nil {
+		return s, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return "", fmt.Errorf("want RFC3339 or %s, got %q", layout, s)
+	}
+	return t.Format(layout), nil
+}
+
+// FormatQueryResult renders a query result for LLM consumption in the
+// requested format: "json" (default), "csv", or "text" (a compact
+// tabular block with cells separated by " | ").
+func FormatQueryResult(result *RedashQueryResult, format string) (string, error) {
+	switch format {
+	case "", "json":
+		b, err := json.Marshal(result.Data)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	case "csv":
+		var buf strings.Builder
+		w := csv.NewWriter(&buf)
+		header := make([]string, len(result.Data.Columns))
+		for i, col := range result.Data.Columns {
+			header[i] = col.Name
+		}
+		if err := w.Write(header); err != nil {
+			return "", err
+		}
+		for _, row := range result.Data.Rows {
+			record := make([]string, len(header))
+			for i, name := range header {
+				record[i] = fmt.Sprintf("%v", row[name])
+			}
+			if err := w.Write(record); err != nil {
+				return "", err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	case "text":
+		var buf strings.Builder
+		header := make([]string, len(result.Data.Columns))
+		for i, col := range result.Data.Columns {
+			header[i] = col.Name
+		}
+		buf.WriteString(strings.Join(header, " | "))
+		buf.WriteString("\n")
+		for _, row := range result.Data.Rows {
+			values := make([]string, len(header))
+			for i, name := range header {
+				values[i] = fmt.Sprintf("%v", row[name])
+			}
+			buf.WriteString(strings.Join(values, " | "))
+			buf.WriteString("\n")
+		}
+		return buf.String(), nil
+	default:
+		return "", fmt.Errorf("unknown format %q (want json, csv, or text)", format)
 	}
-	return result, nil
 }
 
 // Args and result for update_query
@@ -194,31 +826,14 @@ type UpdateQueryResult struct {
 
 // RedashClient: update an existing query
 func (c *RedashClient) UpdateQuery(ctx context.Context, args UpdateQueryArgs) (*RedashQueryDetail, error) {
-	endpoint := fmt.Sprintf("%s/api/queries/%d", c.BaseURL, args.ID)
-	body, err := json.Marshal(map[string]interface{}{
+	body := map[string]interface{}{
 		"name":           args.Name,
 		"query":          args.Query,
 		"data_source_id": args.DataSourceID,
-	})
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Key "+c.APIKey)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Redash API request failed: %s", resp.Status)
 	}
 	var result RedashQueryDetail
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	path := fmt.Sprintf("/api/queries/%d", args.ID)
+	if err := c.do(ctx, http.MethodPost, path, body, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -237,21 +852,8 @@ type ArchiveQueryResult struct {
 
 // RedashClient: archive (soft-delete) a query
 func (c *RedashClient) ArchiveQuery(ctx context.Context, id int) error {
-	endpoint := fmt.Sprintf("%s/api/queries/%d", c.BaseURL, id)
-	req, err := http.NewRequestWithContext(ctx, "DELETE", endpoint, nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Authorization", "Key "+c.APIKey)
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Redash API request failed: %s", resp.Status)
-	}
-	return nil
+	path := fmt.Sprintf("/api/queries/%d", id)
+	return c.do(ctx, http.MethodDelete, path, nil, nil)
 }
 
 // Structs for list_data_sources
@@ -277,293 +879,1337 @@ func (c *RedashClient) ListDataSources(ctx context.Context) ([]RedashDataSource,
 	return result, nil
 }
 
-// MCP tool to fetch Redash query list
+// RedashSchemaTable describes one table of a data source's schema, as
+// returned by /api/data_sources/{id}/schema.
+type RedashSchemaTable struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+}
 
-type ListQueriesArgs struct{}
+// schemaCacheEntry is one cached /schema response, along with when it
+// expires.
+type schemaCacheEntry struct {
+	tables    []RedashSchemaTable
+	expiresAt time.Time
+}
 
-type ListQueriesResult struct {
-	Queries []RedashQuery `json:"queries"`
+// hashCredential returns a fixed-size, non-reversible fingerprint of an
+// Authorization header value, suitable for use as part of a cache key
+// without retaining the credential itself in memory.
+func hashCredential(authHeader string) string {
+	sum := sha256.Sum256([]byte(authHeader))
+	return hex.EncodeToString(sum[:])
 }
 
-func ListQueries(
-	ctx context.Context,
-	ss *mcp.ServerSession,
-	params *mcp.CallToolParamsFor[ListQueriesArgs],
-) (*mcp.CallToolResultFor[ListQueriesResult], error) {
-	client, err := NewRedashClientFromEnv()
+// GetDataSourceSchema returns the tables and columns of a data source,
+// so an LLM can ground SQL generation without guessing column names.
+// Because schema lookups are slow and called frequently, the result is
+// cached for schemaCacheTTL unless refresh is true. The cache is scoped to
+// c's credentials, so two RedashClients with different credentials never
+// share a cached schema even for the same data source.
+func (c *RedashClient) GetDataSourceSchema(ctx context.Context, dataSourceID int, refresh bool) ([]RedashSchemaTable, error) {
+	authHeader, err := c.Credentials.AuthHeader(ctx)
 	if err != nil {
-		return &mcp.CallToolResultFor[ListQueriesResult]{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to create Redash client: %v", err)},
-				&mcp.TextContent{Text: `{"queries":[]}`},
-			},
-		}, nil
+		return nil, err
 	}
-	queries, err := client.GetQueries(ctx)
-	if err != nil {
-		return &mcp.CallToolResultFor[ListQueriesResult]{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to fetch queries: %v", err)},
-				&mcp.TextContent{Text: `{"queries":[]}`},
-			},
-		}, nil
+	key := schemaCacheKey{dataSourceID: dataSourceID, credentialHash: hashCredential(authHeader)}
+
+	if !refresh {
+		schemaCacheMu.Lock()
+		entry, ok := schemaCache[key]
+		schemaCacheMu.Unlock()
+		if ok && time.Now().Before(entry.expiresAt) {
+			return entry.tables, nil
+		}
 	}
-	jsonBytes, err := json.Marshal(ListQueriesResult{Queries: queries})
-	if err != nil {
+
+	var result struct {
+		Schema []RedashSchemaTable `json:"schema"`
+	}
+	endpoint := fmt.Sprintf("/api/data_sources/%d/schema", dataSourceID)
+	if err := c.get(ctx, endpoint, &result); err != nil {
 		return nil, err
 	}
-	return &mcp.CallToolResultFor[ListQueriesResult]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: fmt.Sprintf("Fetched %d queries.", len(queries))},
-			&mcp.TextContent{Text: string(jsonBytes)},
-		},
-	}, nil
+
+	schemaCacheMu.Lock()
+	schemaCache[key] = schemaCacheEntry{tables: result.Schema, expiresAt: time.Now().Add(c.schemaCacheTTL)}
+	schemaCacheMu.Unlock()
+	return result.Schema, nil
 }
 
-// MCP tool: get_query
+// SearchSchema filters a data source's schema down to tables and
+// columns whose name contains substr (case-insensitive), so an agent
+// doesn't have to pull a whole schema into its context to find one
+// column. A table whose own name matches is returned in full; otherwise
+// only its matching columns are kept.
+func SearchSchema(tables []RedashSchemaTable, substr string) []RedashSchemaTable {
+	needle := strings.ToLower(substr)
+	var matches []RedashSchemaTable
+	for _, table := range tables {
+		if strings.Contains(strings.ToLower(table.Name), needle) {
+			matches = append(matches, table)
+			continue
+		}
+		var cols []string
+		for _, col := range table.Columns {
+			if strings.Contains(strings.ToLower(col), needle) {
+				cols = append(cols, col)
+			}
+		}
+		if len(cols) > 0 {
+			matches = append(matches, RedashSchemaTable{Name: table.Name, Columns: cols})
+		}
+	}
+	return matches
+}
 
-type GetQueryArgs struct {
-	ID int `json:"id"`
+// Structs for dashboards
+
+type RedashDashboard struct {
+	ID         int            `json:"id"`
+	Slug       string         `json:"slug"`
+	Name       string         `json:"name"`
+	IsArchived bool           `json:"is_archived"`
+	Widgets    []RedashWidget `json:"widgets,omitempty"`
 }
-type GetQueryResult struct {
-	Query *RedashQueryDetail `json:"query"`
+
+type RedashDashboardListResponse struct {
+	Results []RedashDashboard `json:"results"`
 }
 
-func GetQuery(
-	ctx context.Context,
-	ss *mcp.ServerSession,
-	params *mcp.CallToolParamsFor[GetQueryArgs],
-) (*mcp.CallToolResultFor[GetQueryResult], error) {
-	client, err := NewRedashClientFromEnv()
-	if err != nil {
-		return &mcp.CallToolResultFor[GetQueryResult]{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to create Redash client: %v", err)},
-				&mcp.TextContent{Text: `{"query":null}`},
-			},
-		}, nil
+type RedashWidget struct {
+	ID              int                    `json:"id"`
+	DashboardID     int                    `json:"dashboard_id"`
+	VisualizationID int                    `json:"visualization_id,omitempty"`
+	Text            string                 `json:"text,omitempty"`
+	Options         map[string]interface{} `json:"options,omitempty"`
+}
+
+// RedashClient: list all dashboards
+func (c *RedashClient) ListDashboards(ctx context.Context) ([]RedashDashboard, error) {
+	var result RedashDashboardListResponse
+	if err := c.get(ctx, "/api/dashboards", &result); err != nil {
+		return nil, err
 	}
-	query, err := client.GetQueryByID(ctx, params.Arguments.ID)
-	if err != nil {
-		return &mcp.CallToolResultFor[GetQueryResult]{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to fetch query: %v", err)},
-				&mcp.TextContent{Text: `{"query":null}`},
-			},
-		}, nil
+	return result.Results, nil
+}
+
+// RedashClient: fetch a dashboard (and its widgets) by slug
+func (c *RedashClient) GetDashboard(ctx context.Context, slug string) (*RedashDashboard, error) {
+	var result RedashDashboard
+	endpoint := fmt.Sprintf("/api/dashboards/%s", slug)
+	if err := c.get(ctx, endpoint, &result); err != nil {
+		return nil, err
 	}
-	jsonBytes, err := json.Marshal(GetQueryResult{Query: query})
-	if err != nil {
+	return &result, nil
+}
+
+// RedashClient: create a new, empty dashboard
+func (c *RedashClient) CreateDashboard(ctx context.Context, name string) (*RedashDashboard, error) {
+	var result RedashDashboard
+	if err := c.do(ctx, http.MethodPost, "/api/dashboards", map[string]interface{}{"name": name}, &result); err != nil {
 		return nil, err
 	}
-	return &mcp.CallToolResultFor[GetQueryResult]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: "Fetched query details."},
-			&mcp.TextContent{Text: string(jsonBytes)},
-		},
-	}, nil
+	return &result, nil
 }
 
-// MCP tool: create_query
-func CreateQuery(
-	ctx context.Context,
-	ss *mcp.ServerSession,
-	params *mcp.CallToolParamsFor[CreateQueryArgs],
-) (*mcp.CallToolResultFor[CreateQueryResult], error) {
-	client, err := NewRedashClientFromEnv()
-	if err != nil {
-		return &mcp.CallToolResultFor[CreateQueryResult]{
+// Args for add_widget
+
+type AddWidgetArgs struct {
+	DashboardID     int    `json:"dashboard_id"`
+	VisualizationID int    `json:"visualization_id,omitempty"`
+	Text            string `json:"text,omitempty"`
+}
+
+// RedashClient: add a widget (a visualization or a text box) to a dashboard
+func (c *RedashClient) AddWidget(ctx context.Context, args AddWidgetArgs) (*RedashWidget, error) {
+	body := map[string]interface{}{
+		"dashboard_id": args.DashboardID,
+		"text":         args.Text,
+		"options":      map[string]interface{}{},
+	}
+	if args.VisualizationID != 0 {
+		body["visualization_id"] = args.VisualizationID
+	}
+	var result RedashWidget
+	if err := c.do(ctx, http.MethodPost, "/api/widgets", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Structs for alerts
+
+type RedashAlert struct {
+	ID      int                `json:"id"`
+	Name    string             `json:"name"`
+	QueryID int                `json:"query_id"`
+	State   string             `json:"state"` // "ok", "triggered", or "unknown"
+	Rearm   int                `json:"rearm,omitempty"`
+	Options RedashAlertOptions `json:"options"`
+}
+
+type RedashAlertOptions struct {
+	Column string      `json:"column"`
+	Op     string      `json:"op"` // e.g. "greater than", "less than", "equals"
+	Value  interface{} `json:"value"`
+}
+
+// RedashClient: list all alerts
+func (c *RedashClient) ListAlerts(ctx context.Context) ([]RedashAlert, error) {
+	var result []RedashAlert
+	if err := c.get(ctx, "/api/alerts", &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Args for create_alert
+
+type CreateAlertArgs struct {
+	Name    string             `json:"name"`
+	QueryID int                `json:"query_id"`
+	Options RedashAlertOptions `json:"options"`
+}
+
+// RedashClient: create a new alert on a query
+func (c *RedashClient) CreateAlert(ctx context.Context, args CreateAlertArgs) (*RedashAlert, error) {
+	body := map[string]interface{}{
+		"name":     args.Name,
+		"query_id": args.QueryID,
+		"options":  args.Options,
+	}
+	var result RedashAlert
+	if err := c.do(ctx, http.MethodPost, "/api/alerts", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RedashClient: mute (pause) or unmute an alert's notifications
+func (c *RedashClient) PauseAlert(ctx context.Context, id int, pause bool) error {
+	path := fmt.Sprintf("/api/alerts/%d/mute", id)
+	if pause {
+		return c.do(ctx, http.MethodPost, path, nil, nil)
+	}
+	return c.do(ctx, http.MethodDelete, path, nil, nil)
+}
+
+// Structs for visualizations
+
+type RedashVisualization struct {
+	ID      int                    `json:"id"`
+	Type    string                 `json:"type"` // e.g. "CHART", "TABLE", "COUNTER"
+	Name    string                 `json:"name"`
+	QueryID int                    `json:"query_id,omitempty"`
+	Options map[string]interface{} `json:"options"`
+}
+
+// RedashClient: list the visualizations already defined for a query
+func (c *RedashClient) ListVisualizationsForQuery(ctx context.Context, queryID int) ([]RedashVisualization, error) {
+	var result struct {
+		Visualizations []RedashVisualization `json:"visualizations"`
+	}
+	endpoint := fmt.Sprintf("/api/queries/%d", queryID)
+	if err := c.get(ctx, endpoint, &result); err != nil {
+		return nil, err
+	}
+	return result.Visualizations, nil
+}
+
+// Args for create_visualization
+
+type CreateVisualizationArgs struct {
+	QueryID int                    `json:"query_id"`
+	Type    string                 `json:"type"`
+	Name    string                 `json:"name"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// RedashClient: create a new visualization for a query
+func (c *RedashClient) CreateVisualization(ctx context.Context, args CreateVisualizationArgs) (*RedashVisualization, error) {
+	options := args.Options
+	if options == nil {
+		options = map[string]interface{}{}
+	}
+	body := map[string]interface{}{
+		"query_id": args.QueryID,
+		"type":     args.Type,
+		"name":     args.Name,
+		"options":  options,
+	}
+	var result RedashVisualization
+	if err := c.do(ctx, http.MethodPost, "/api/visualizations", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Structs for users and groups
+
+type RedashUser struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type RedashUserListResponse struct {
+	Results []RedashUser `json:"results"`
+}
+
+type RedashGroup struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// RedashClient: list all users
+func (c *RedashClient) ListUsers(ctx context.Context) ([]RedashUser, error) {
+	var result RedashUserListResponse
+	if err := c.get(ctx, "/api/users", &result); err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// RedashClient: list all groups
+func (c *RedashClient) ListGroups(ctx context.Context) ([]RedashGroup, error) {
+	var result []RedashGroup
+	if err := c.get(ctx, "/api/groups", &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MCP tool to fetch Redash query list
+
+type ListQueriesArgs struct{}
+
+type ListQueriesResult struct {
+	Queries []RedashQuery `json:"queries"`
+}
+
+func ListQueries(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[ListQueriesArgs],
+) (*mcp.CallToolResultFor[ListQueriesResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[ListQueriesArgs],
+	) (*mcp.CallToolResultFor[ListQueriesResult], error) {
+		queries, err := client.GetQueries(ctx)
+		if err != nil {
+			return &mcp.CallToolResultFor[ListQueriesResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to fetch queries", err)},
+					&mcp.TextContent{Text: `{"queries":[]}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(ListQueriesResult{Queries: queries})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[ListQueriesResult]{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to create Redash client: %v", err)},
-				&mcp.TextContent{Text: `{"query":null}`},
+				&mcp.TextContent{Text: fmt.Sprintf("Fetched %d queries.", len(queries))},
+				&mcp.TextContent{Text: string(jsonBytes)},
 			},
 		}, nil
 	}
-	query, err := client.CreateQuery(ctx, params.Arguments)
-	if err != nil {
-		return &mcp.CallToolResultFor[CreateQueryResult]{
+}
+
+// MCP tool: get_query
+
+type GetQueryArgs struct {
+	ID int `json:"id"`
+}
+type GetQueryResult struct {
+	Query *RedashQueryDetail `json:"query"`
+}
+
+func GetQuery(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[GetQueryArgs],
+) (*mcp.CallToolResultFor[GetQueryResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[GetQueryArgs],
+	) (*mcp.CallToolResultFor[GetQueryResult], error) {
+		query, err := client.GetQueryByID(ctx, params.Arguments.ID)
+		if err != nil {
+			return &mcp.CallToolResultFor[GetQueryResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to fetch query", err)},
+					&mcp.TextContent{Text: `{"query":null}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(GetQueryResult{Query: query})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[GetQueryResult]{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to create query: %v", err)},
-				&mcp.TextContent{Text: `{"query":null}`},
+				&mcp.TextContent{Text: "Fetched query details."},
+				&mcp.TextContent{Text: string(jsonBytes)},
 			},
 		}, nil
 	}
-	jsonBytes, err := json.Marshal(CreateQueryResult{Query: query})
-	if err != nil {
-		return nil, err
+}
+
+// MCP tool: create_query
+func CreateQuery(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[CreateQueryArgs],
+) (*mcp.CallToolResultFor[CreateQueryResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[CreateQueryArgs],
+	) (*mcp.CallToolResultFor[CreateQueryResult], error) {
+		query, err := client.CreateQuery(ctx, params.Arguments)
+		if err != nil {
+			return &mcp.CallToolResultFor[CreateQueryResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to create query", err)},
+					&mcp.TextContent{Text: `{"query":null}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(CreateQueryResult{Query: query})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[CreateQueryResult]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Created new query."},
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil
 	}
-	return &mcp.CallToolResultFor[CreateQueryResult]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: "Created new query."},
-			&mcp.TextContent{Text: string(jsonBytes)},
-		},
-	}, nil
 }
 
 // MCP tool: execute_query
-func ExecuteQuery(
+func ExecuteQuery(client *RedashClient) func(
 	ctx context.Context,
 	ss *mcp.ServerSession,
 	params *mcp.CallToolParamsFor[ExecuteQueryArgs],
 ) (*mcp.CallToolResultFor[ExecuteQueryResult], error) {
-	client, err := NewRedashClientFromEnv()
-	if err != nil {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[ExecuteQueryArgs],
+	) (*mcp.CallToolResultFor[ExecuteQueryResult], error) {
+		qr, err := client.ExecuteQuery(ctx, params.Arguments.ID, params.Arguments.MaxAge)
+		if err != nil {
+			return &mcp.CallToolResultFor[ExecuteQueryResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to execute query", err)},
+					&mcp.TextContent{Text: `{"query_result":null}`},
+				},
+			}, nil
+		}
+		formatted, err := FormatQueryResult(qr, params.Arguments.Format)
+		if err != nil {
+			return &mcp.CallToolResultFor[ExecuteQueryResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("Failed to format query result: %v", err)},
+					&mcp.TextContent{Text: `{"query_result":null}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(ExecuteQueryResult{QueryResult: qr})
+		if err != nil {
+			return nil, err
+		}
 		return &mcp.CallToolResultFor[ExecuteQueryResult]{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to create Redash client: %v", err)},
-				&mcp.TextContent{Text: `{"query_result":null}`},
+				&mcp.TextContent{Text: "Executed query and fetched result."},
+				&mcp.TextContent{Text: formatted},
+				&mcp.TextContent{Text: string(jsonBytes)},
 			},
 		}, nil
 	}
-	qr, err := client.ExecuteQuery(ctx, params.Arguments.ID)
-	if err != nil {
-		return &mcp.CallToolResultFor[ExecuteQueryResult]{
+}
+
+// Args and result for get_cached_result
+
+type GetCachedResultArgs struct {
+	QueryID int `json:"query_id"`
+	// ResultID selects a specific cached result. If omitted, the
+	// query's most recent result (latest_query_data_id) is used.
+	ResultID int `json:"result_id,omitempty"`
+	// Format controls how the result is rendered for the LLM: "json"
+	// (default), "csv", or "text" (a compact tabular block).
+	Format string `json:"format,omitempty"`
+}
+
+type GetCachedResultResult struct {
+	QueryResult *RedashQueryResult `json:"query_result"`
+}
+
+// MCP tool: get_cached_result
+func GetCachedResult(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[GetCachedResultArgs],
+) (*mcp.CallToolResultFor[GetCachedResultResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[GetCachedResultArgs],
+	) (*mcp.CallToolResultFor[GetCachedResultResult], error) {
+		qr, err := client.GetCachedResult(ctx, params.Arguments.QueryID, params.Arguments.ResultID)
+		if err != nil {
+			return &mcp.CallToolResultFor[GetCachedResultResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to fetch cached result", err)},
+					&mcp.TextContent{Text: `{"query_result":null}`},
+				},
+			}, nil
+		}
+		formatted, err := FormatQueryResult(qr, params.Arguments.Format)
+		if err != nil {
+			return &mcp.CallToolResultFor[GetCachedResultResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("Failed to format query result: %v", err)},
+					&mcp.TextContent{Text: `{"query_result":null}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(GetCachedResultResult{QueryResult: qr})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[GetCachedResultResult]{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to execute query: %v", err)},
-				&mcp.TextContent{Text: `{"query_result":null}`},
+				&mcp.TextContent{Text: "Fetched cached query result."},
+				&mcp.TextContent{Text: formatted},
+				&mcp.TextContent{Text: string(jsonBytes)},
 			},
 		}, nil
 	}
-	jsonBytes, err := json.Marshal(ExecuteQueryResult{QueryResult: qr})
-	if err != nil {
-		return nil, err
+}
+
+// Args and result for execute_query_with_parameters
+
+type ExecuteQueryWithParametersArgs struct {
+	ID         int                    `json:"id"`
+	Parameters map[string]interface{} `json:"parameters"`
+	// MaxAge mirrors execute_query's max_age; see ExecuteQueryArgs.
+	MaxAge int `json:"max_age,omitempty"`
+	// Format controls how the result is rendered for the LLM: "json"
+	// (default), "csv", or "text" (a compact tabular block).
+	Format string `json:"format,omitempty"`
+}
+
+type ExecuteQueryWithParametersResult struct {
+	QueryResult *RedashQueryResult `json:"query_result"`
+}
+
+// MCP tool: execute_query_with_parameters
+func ExecuteQueryWithParameters(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[ExecuteQueryWithParametersArgs],
+) (*mcp.CallToolResultFor[ExecuteQueryWithParametersResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[ExecuteQueryWithParametersArgs],
+	) (*mcp.CallToolResultFor[ExecuteQueryWithParametersResult], error) {
+		qr, err := client.ExecuteQueryWithParameters(ctx, params.Arguments.ID, params.Arguments.MaxAge, params.Arguments.Parameters)
+		if err != nil {
+			return &mcp.CallToolResultFor[ExecuteQueryWithParametersResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to execute query", err)},
+					&mcp.TextContent{Text: `{"query_result":null}`},
+				},
+			}, nil
+		}
+		formatted, err := FormatQueryResult(qr, params.Arguments.Format)
+		if err != nil {
+			return &mcp.CallToolResultFor[ExecuteQueryWithParametersResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("Failed to format query result: %v", err)},
+					&mcp.TextContent{Text: `{"query_result":null}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(ExecuteQueryWithParametersResult{QueryResult: qr})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[ExecuteQueryWithParametersResult]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Executed parameterized query and fetched result."},
+				&mcp.TextContent{Text: formatted},
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil
 	}
-	return &mcp.CallToolResultFor[ExecuteQueryResult]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: "Executed query and fetched result."},
-			&mcp.TextContent{Text: string(jsonBytes)},
-		},
-	}, nil
 }
 
-// MCP tool: update_query
-func UpdateQuery(
+// Args and result for describe_query_parameters
+
+type DescribeQueryParametersArgs struct {
+	ID int `json:"id"`
+}
+
+type DescribeQueryParametersResult struct {
+	Parameters []RedashQueryParameter `json:"parameters"`
+}
+
+// MCP tool: describe_query_parameters
+func DescribeQueryParameters(client *RedashClient) func(
 	ctx context.Context,
 	ss *mcp.ServerSession,
-	params *mcp.CallToolParamsFor[UpdateQueryArgs],
-) (*mcp.CallToolResultFor[UpdateQueryResult], error) {
-	client, err := NewRedashClientFromEnv()
-	if err != nil {
-		return &mcp.CallToolResultFor[UpdateQueryResult]{
+	params *mcp.CallToolParamsFor[DescribeQueryParametersArgs],
+) (*mcp.CallToolResultFor[DescribeQueryParametersResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[DescribeQueryParametersArgs],
+	) (*mcp.CallToolResultFor[DescribeQueryParametersResult], error) {
+		detail, err := client.GetQueryByID(ctx, params.Arguments.ID)
+		if err != nil {
+			return &mcp.CallToolResultFor[DescribeQueryParametersResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to fetch query", err)},
+					&mcp.TextContent{Text: `{"parameters":[]}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(DescribeQueryParametersResult{Parameters: detail.Options.Parameters})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[DescribeQueryParametersResult]{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to create Redash client: %v", err)},
-				&mcp.TextContent{Text: `{"query":null}`},
+				&mcp.TextContent{Text: fmt.Sprintf("Query %d has %d parameter(s).", params.Arguments.ID, len(detail.Options.Parameters))},
+				&mcp.TextContent{Text: string(jsonBytes)},
 			},
 		}, nil
 	}
-	query, err := client.UpdateQuery(ctx, params.Arguments)
-	if err != nil {
+}
+
+// MCP tool: update_query
+func UpdateQuery(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[UpdateQueryArgs],
+) (*mcp.CallToolResultFor[UpdateQueryResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[UpdateQueryArgs],
+	) (*mcp.CallToolResultFor[UpdateQueryResult], error) {
+		query, err := client.UpdateQuery(ctx, params.Arguments)
+		if err != nil {
+			return &mcp.CallToolResultFor[UpdateQueryResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to update query", err)},
+					&mcp.TextContent{Text: `{"query":null}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(UpdateQueryResult{Query: query})
+		if err != nil {
+			return nil, err
+		}
 		return &mcp.CallToolResultFor[UpdateQueryResult]{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to update query: %v", err)},
-				&mcp.TextContent{Text: `{"query":null}`},
+				&mcp.TextContent{Text: "Updated query."},
+				&mcp.TextContent{Text: string(jsonBytes)},
 			},
 		}, nil
 	}
-	jsonBytes, err := json.Marshal(UpdateQueryResult{Query: query})
-	if err != nil {
-		return nil, err
-	}
-	return &mcp.CallToolResultFor[UpdateQueryResult]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: "Updated query."},
-			&mcp.TextContent{Text: string(jsonBytes)},
-		},
-	}, nil
 }
 
 // MCP tool: archive_query
-func ArchiveQuery(
+func ArchiveQuery(client *RedashClient) func(
 	ctx context.Context,
 	ss *mcp.ServerSession,
 	params *mcp.CallToolParamsFor[ArchiveQueryArgs],
 ) (*mcp.CallToolResultFor[ArchiveQueryResult], error) {
-	client, err := NewRedashClientFromEnv()
-	if err != nil {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[ArchiveQueryArgs],
+	) (*mcp.CallToolResultFor[ArchiveQueryResult], error) {
+		err := client.ArchiveQuery(ctx, params.Arguments.ID)
+		if err != nil {
+			return &mcp.CallToolResultFor[ArchiveQueryResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to archive query", err)},
+					&mcp.TextContent{Text: fmt.Sprintf(`{"success":false,"message":"%v"}`, err)},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(ArchiveQueryResult{Success: true, Message: "Query archived."})
+		if err != nil {
+			return nil, err
+		}
 		return &mcp.CallToolResultFor[ArchiveQueryResult]{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to create Redash client: %v", err)},
-				&mcp.TextContent{Text: `{"success":false,"message":"client error"}`},
+				&mcp.TextContent{Text: "Query archived."},
+				&mcp.TextContent{Text: string(jsonBytes)},
 			},
 		}, nil
 	}
-	err = client.ArchiveQuery(ctx, params.Arguments.ID)
-	if err != nil {
-		return &mcp.CallToolResultFor[ArchiveQueryResult]{
+}
+
+// MCP tool: list_data_sources
+func ListDataSources(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[struct{}],
+) (*mcp.CallToolResultFor[ListDataSourcesResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[struct{}],
+	) (*mcp.CallToolResultFor[ListDataSourcesResult], error) {
+		ds, err := client.ListDataSources(ctx)
+		if err != nil {
+			return &mcp.CallToolResultFor[ListDataSourcesResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to fetch data sources", err)},
+					&mcp.TextContent{Text: `{"data_sources":[]}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(ListDataSourcesResult{DataSources: ds})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[ListDataSourcesResult]{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to archive query: %v", err)},
-				&mcp.TextContent{Text: fmt.Sprintf(`{"success":false,"message":"%v"}`, err)},
+				&mcp.TextContent{Text: fmt.Sprintf("Fetched %d data sources.", len(ds))},
+				&mcp.TextContent{Text: string(jsonBytes)},
 			},
 		}, nil
 	}
-	jsonBytes, err := json.Marshal(ArchiveQueryResult{Success: true, Message: "Query archived."})
-	if err != nil {
-		return nil, err
+}
+
+// Result for list_dashboards
+
+type ListDashboardsResult struct {
+	Dashboards []RedashDashboard `json:"dashboards"`
+}
+
+// MCP tool: list_dashboards
+func ListDashboards(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[struct{}],
+) (*mcp.CallToolResultFor[ListDashboardsResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[struct{}],
+	) (*mcp.CallToolResultFor[ListDashboardsResult], error) {
+		dashboards, err := client.ListDashboards(ctx)
+		if err != nil {
+			return &mcp.CallToolResultFor[ListDashboardsResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to fetch dashboards", err)},
+					&mcp.TextContent{Text: `{"dashboards":[]}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(ListDashboardsResult{Dashboards: dashboards})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[ListDashboardsResult]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Fetched %d dashboards.", len(dashboards))},
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil
 	}
-	return &mcp.CallToolResultFor[ArchiveQueryResult]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: "Query archived."},
-			&mcp.TextContent{Text: string(jsonBytes)},
-		},
-	}, nil
 }
 
-// MCP tool: list_data_sources
-func ListDataSources(
+// Args and result for get_dashboard
+
+type GetDashboardArgs struct {
+	Slug string `json:"slug"`
+}
+
+type GetDashboardResult struct {
+	Dashboard *RedashDashboard `json:"dashboard"`
+}
+
+// MCP tool: get_dashboard
+func GetDashboard(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[GetDashboardArgs],
+) (*mcp.CallToolResultFor[GetDashboardResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[GetDashboardArgs],
+	) (*mcp.CallToolResultFor[GetDashboardResult], error) {
+		dashboard, err := client.GetDashboard(ctx, params.Arguments.Slug)
+		if err != nil {
+			return &mcp.CallToolResultFor[GetDashboardResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to fetch dashboard", err)},
+					&mcp.TextContent{Text: `{"dashboard":null}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(GetDashboardResult{Dashboard: dashboard})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[GetDashboardResult]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Fetched dashboard details."},
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil
+	}
+}
+
+// Args and result for create_dashboard
+
+type CreateDashboardArgs struct {
+	Name string `json:"name"`
+}
+
+type CreateDashboardResult struct {
+	Dashboard *RedashDashboard `json:"dashboard"`
+}
+
+// MCP tool: create_dashboard
+func CreateDashboard(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[CreateDashboardArgs],
+) (*mcp.CallToolResultFor[CreateDashboardResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[CreateDashboardArgs],
+	) (*mcp.CallToolResultFor[CreateDashboardResult], error) {
+		dashboard, err := client.CreateDashboard(ctx, params.Arguments.Name)
+		if err != nil {
+			return &mcp.CallToolResultFor[CreateDashboardResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to create dashboard", err)},
+					&mcp.TextContent{Text: `{"dashboard":null}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(CreateDashboardResult{Dashboard: dashboard})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[CreateDashboardResult]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Created new dashboard."},
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil
+	}
+}
+
+// Result for add_widget
+
+type AddWidgetResult struct {
+	Widget *RedashWidget `json:"widget"`
+}
+
+// MCP tool: add_widget
+func AddWidget(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[AddWidgetArgs],
+) (*mcp.CallToolResultFor[AddWidgetResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[AddWidgetArgs],
+	) (*mcp.CallToolResultFor[AddWidgetResult], error) {
+		widget, err := client.AddWidget(ctx, params.Arguments)
+		if err != nil {
+			return &mcp.CallToolResultFor[AddWidgetResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to add widget", err)},
+					&mcp.TextContent{Text: `{"widget":null}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(AddWidgetResult{Widget: widget})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[AddWidgetResult]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Added widget to dashboard."},
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil
+	}
+}
+
+// Result for list_alerts
+
+type ListAlertsResult struct {
+	Alerts []RedashAlert `json:"alerts"`
+}
+
+// MCP tool: list_alerts
+func ListAlerts(client *RedashClient) func(
 	ctx context.Context,
 	ss *mcp.ServerSession,
 	params *mcp.CallToolParamsFor[struct{}],
-) (*mcp.CallToolResultFor[ListDataSourcesResult], error) {
-	client, err := NewRedashClientFromEnv()
-	if err != nil {
-		return &mcp.CallToolResultFor[ListDataSourcesResult]{
+) (*mcp.CallToolResultFor[ListAlertsResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[struct{}],
+	) (*mcp.CallToolResultFor[ListAlertsResult], error) {
+		alerts, err := client.ListAlerts(ctx)
+		if err != nil {
+			return &mcp.CallToolResultFor[ListAlertsResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to fetch alerts", err)},
+					&mcp.TextContent{Text: `{"alerts":[]}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(ListAlertsResult{Alerts: alerts})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[ListAlertsResult]{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to create Redash client: %v", err)},
-				&mcp.TextContent{Text: `{"data_sources":[]}`},
+				&mcp.TextContent{Text: fmt.Sprintf("Fetched %d alerts.", len(alerts))},
+				&mcp.TextContent{Text: string(jsonBytes)},
 			},
 		}, nil
 	}
-	ds, err := client.ListDataSources(ctx)
-	if err != nil {
-		return &mcp.CallToolResultFor[ListDataSourcesResult]{
+}
+
+// Result for create_alert
+
+type CreateAlertResult struct {
+	Alert *RedashAlert `json:"alert"`
+}
+
+// MCP tool: create_alert
+func CreateAlert(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[CreateAlertArgs],
+) (*mcp.CallToolResultFor[CreateAlertResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[CreateAlertArgs],
+	) (*mcp.CallToolResultFor[CreateAlertResult], error) {
+		alert, err := client.CreateAlert(ctx, params.Arguments)
+		if err != nil {
+			return &mcp.CallToolResultFor[CreateAlertResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to create alert", err)},
+					&mcp.TextContent{Text: `{"alert":null}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(CreateAlertResult{Alert: alert})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[CreateAlertResult]{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Failed to fetch data sources: %v", err)},
-				&mcp.TextContent{Text: `{"data_sources":[]}`},
+				&mcp.TextContent{Text: "Created new alert."},
+				&mcp.TextContent{Text: string(jsonBytes)},
 			},
 		}, nil
 	}
-	jsonBytes, err := json.Marshal(ListDataSourcesResult{DataSources: ds})
-	if err != nil {
-		return nil, err
+}
+
+// Args and result for pause_alert
+
+type PauseAlertArgs struct {
+	ID    int  `json:"id"`
+	Pause bool `json:"pause"`
+}
+
+type PauseAlertResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// MCP tool: pause_alert
+func PauseAlert(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[PauseAlertArgs],
+) (*mcp.CallToolResultFor[PauseAlertResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[PauseAlertArgs],
+	) (*mcp.CallToolResultFor[PauseAlertResult], error) {
+		if err := client.PauseAlert(ctx, params.Arguments.ID, params.Arguments.Pause); err != nil {
+			return &mcp.CallToolResultFor[PauseAlertResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to update alert", err)},
+					&mcp.TextContent{Text: fmt.Sprintf(`{"success":false,"message":"%v"}`, err)},
+				},
+			}, nil
+		}
+		message := "Alert paused."
+		if !params.Arguments.Pause {
+			message = "Alert unpaused."
+		}
+		jsonBytes, err := json.Marshal(PauseAlertResult{Success: true, Message: message})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[PauseAlertResult]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: message},
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil
 	}
-	return &mcp.CallToolResultFor[ListDataSourcesResult]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: fmt.Sprintf("Fetched %d data sources.", len(ds))},
-			&mcp.TextContent{Text: string(jsonBytes)},
-		},
-	}, nil
+}
+
+// Args and result for list_visualizations_for_query
+
+type ListVisualizationsForQueryArgs struct {
+	QueryID int `json:"query_id"`
+}
+
+type ListVisualizationsForQueryResult struct {
+	Visualizations []RedashVisualization `json:"visualizations"`
+}
+
+// MCP tool: list_visualizations_for_query
+func ListVisualizationsForQuery(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[ListVisualizationsForQueryArgs],
+) (*mcp.CallToolResultFor[ListVisualizationsForQueryResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[ListVisualizationsForQueryArgs],
+	) (*mcp.CallToolResultFor[ListVisualizationsForQueryResult], error) {
+		visualizations, err := client.ListVisualizationsForQuery(ctx, params.Arguments.QueryID)
+		if err != nil {
+			return &mcp.CallToolResultFor[ListVisualizationsForQueryResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to fetch visualizations", err)},
+					&mcp.TextContent{Text: `{"visualizations":[]}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(ListVisualizationsForQueryResult{Visualizations: visualizations})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[ListVisualizationsForQueryResult]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Fetched %d visualizations for query %d.", len(visualizations), params.Arguments.QueryID)},
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil
+	}
+}
+
+// Result for create_visualization
+
+type CreateVisualizationResult struct {
+	Visualization *RedashVisualization `json:"visualization"`
+}
+
+// MCP tool: create_visualization
+func CreateVisualization(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[CreateVisualizationArgs],
+) (*mcp.CallToolResultFor[CreateVisualizationResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[CreateVisualizationArgs],
+	) (*mcp.CallToolResultFor[CreateVisualizationResult], error) {
+		visualization, err := client.CreateVisualization(ctx, params.Arguments)
+		if err != nil {
+			return &mcp.CallToolResultFor[CreateVisualizationResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to create visualization", err)},
+					&mcp.TextContent{Text: `{"visualization":null}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(CreateVisualizationResult{Visualization: visualization})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[CreateVisualizationResult]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Created new visualization."},
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil
+	}
+}
+
+// Result for list_users
+
+type ListUsersResult struct {
+	Users []RedashUser `json:"users"`
+}
+
+// MCP tool: list_users
+func ListUsers(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[struct{}],
+) (*mcp.CallToolResultFor[ListUsersResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[struct{}],
+	) (*mcp.CallToolResultFor[ListUsersResult], error) {
+		users, err := client.ListUsers(ctx)
+		if err != nil {
+			return &mcp.CallToolResultFor[ListUsersResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to fetch users", err)},
+					&mcp.TextContent{Text: `{"users":[]}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(ListUsersResult{Users: users})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[ListUsersResult]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Fetched %d users.", len(users))},
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil
+	}
+}
+
+// Result for list_groups
+
+type ListGroupsResult struct {
+	Groups []RedashGroup `json:"groups"`
+}
+
+// MCP tool: list_groups
+func ListGroups(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[struct{}],
+) (*mcp.CallToolResultFor[ListGroupsResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[struct{}],
+	) (*mcp.CallToolResultFor[ListGroupsResult], error) {
+		groups, err := client.ListGroups(ctx)
+		if err != nil {
+			return &mcp.CallToolResultFor[ListGroupsResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to fetch groups", err)},
+					&mcp.TextContent{Text: `{"groups":[]}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(ListGroupsResult{Groups: groups})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[ListGroupsResult]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Fetched %d groups.", len(groups))},
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil
+	}
+}
+
+// Args and result for get_data_source_schema
+
+type GetDataSourceSchemaArgs struct {
+	DataSourceID int `json:"data_source_id"`
+	// Refresh bypasses the schema cache and refetches from Redash.
+	Refresh bool `json:"refresh,omitempty"`
+}
+
+type GetDataSourceSchemaResult struct {
+	Tables []RedashSchemaTable `json:"tables"`
+}
+
+// MCP tool: get_data_source_schema
+func GetDataSourceSchema(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[GetDataSourceSchemaArgs],
+) (*mcp.CallToolResultFor[GetDataSourceSchemaResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[GetDataSourceSchemaArgs],
+	) (*mcp.CallToolResultFor[GetDataSourceSchemaResult], error) {
+		tables, err := client.GetDataSourceSchema(ctx, params.Arguments.DataSourceID, params.Arguments.Refresh)
+		if err != nil {
+			return &mcp.CallToolResultFor[GetDataSourceSchemaResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to fetch data source schema", err)},
+					&mcp.TextContent{Text: `{"tables":[]}`},
+				},
+			}, nil
+		}
+		jsonBytes, err := json.Marshal(GetDataSourceSchemaResult{Tables: tables})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[GetDataSourceSchemaResult]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Fetched schema for data source %d (%d tables).", params.Arguments.DataSourceID, len(tables))},
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil
+	}
+}
+
+// Args and result for search_schema
+
+type SearchSchemaArgs struct {
+	DataSourceID int    `json:"data_source_id"`
+	Query        string `json:"query"`
+	// Refresh bypasses the schema cache and refetches from Redash.
+	Refresh bool `json:"refresh,omitempty"`
+}
+
+type SearchSchemaResult struct {
+	Tables []RedashSchemaTable `json:"tables"`
+}
+
+// MCP tool: search_schema
+func SearchSchemaTool(client *RedashClient) func(
+	ctx context.Context,
+	ss *mcp.ServerSession,
+	params *mcp.CallToolParamsFor[SearchSchemaArgs],
+) (*mcp.CallToolResultFor[SearchSchemaResult], error) {
+	return func(
+		ctx context.Context,
+		ss *mcp.ServerSession,
+		params *mcp.CallToolParamsFor[SearchSchemaArgs],
+	) (*mcp.CallToolResultFor[SearchSchemaResult], error) {
+		tables, err := client.GetDataSourceSchema(ctx, params.Arguments.DataSourceID, params.Arguments.Refresh)
+		if err != nil {
+			return &mcp.CallToolResultFor[SearchSchemaResult]{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: redashErrMessage("Failed to fetch data source schema", err)},
+					&mcp.TextContent{Text: `{"tables":[]}`},
+				},
+			}, nil
+		}
+		matches := SearchSchema(tables, params.Arguments.Query)
+		jsonBytes, err := json.Marshal(SearchSchemaResult{Tables: matches})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.CallToolResultFor[SearchSchemaResult]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Found %d matching table(s) for %q.", len(matches), params.Arguments.Query)},
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil
+	}
+}
+
+// registerTools wires every Redash MCP tool to server, backed by client.
+func registerTools(server *mcp.Server, client *RedashClient) {
+	mcp.AddTool(server, &mcp.Tool{Name: "list_queries", Description: "Get a list of Redash queries"}, ListQueries(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "get_query", Description: "Get details of a specific Redash query"}, GetQuery(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "create_query", Description: "Create a new Redash query"}, CreateQuery(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "execute_query", Description: "Execute a Redash query and return the result, reusing a fresh cached result when possible"}, ExecuteQuery(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "get_cached_result", Description: "Fetch a previously computed Redash query result without executing the query"}, GetCachedResult(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "execute_query_with_parameters", Description: "Execute a parameterized Redash query with the given parameter values"}, ExecuteQueryWithParameters(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "describe_query_parameters", Description: "Get the parameter schema of a Redash query"}, DescribeQueryParameters(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "update_query", Description: "Update an existing Redash query"}, UpdateQuery(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "list_data_sources", Description: "List all available Redash data sources"}, ListDataSources(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "archive_query", Description: "Archive (soft-delete) a Redash query"}, ArchiveQuery(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "list_dashboards", Description: "List all Redash dashboards"}, ListDashboards(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "get_dashboard", Description: "Get a Redash dashboard and its widgets by slug"}, GetDashboard(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "create_dashboard", Description: "Create a new, empty Redash dashboard"}, CreateDashboard(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "add_widget", Description: "Add a visualization or text widget to a Redash dashboard"}, AddWidget(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "list_alerts", Description: "List all Redash alerts"}, ListAlerts(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "create_alert", Description: "Create a new Redash alert on a query"}, CreateAlert(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "pause_alert", Description: "Pause or unpause notifications for a Redash alert"}, PauseAlert(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "list_visualizations_for_query", Description: "List the visualizations defined for a Redash query"}, ListVisualizationsForQuery(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "create_visualization", Description: "Create a new visualization for a Redash query"}, CreateVisualization(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "list_users", Description: "List all Redash users"}, ListUsers(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "list_groups", Description: "List all Redash groups"}, ListGroups(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "get_data_source_schema", Description: "Get the tables and columns of a Redash data source, for SQL grounding"}, GetDataSourceSchema(client))
+	mcp.AddTool(server, &mcp.Tool{Name: "search_schema", Description: "Search a Redash data source's schema for tables or columns matching a substring"}, SearchSchemaTool(client))
+}
+
+// clientForRequest resolves the RedashClient to use for an incoming HTTP
+// request: the Authorization header forwarded by the caller, if present, so
+// each caller acts with its own Redash credentials; otherwise the
+// process-wide REDASH_API_KEY client, for backwards-compatible single-tenant
+// deployments.
+func clientForRequest(req *http.Request, baseURL string, fallback *RedashClient) *RedashClient {
+	if header := req.Header.Get("Authorization"); header != "" {
+		return NewRedashClient(baseURL, AuthHeaderCredential(header))
+	}
+	return fallback
 }
 
 func main() {
 	flag.Parse()
 
-	server := mcp.NewServer(&mcp.Implementation{Name: "greeter"}, nil)
-	mcp.AddTool(server, &mcp.Tool{Name: "list_queries", Description: "Get a list of Redash queries"}, ListQueries)
-	mcp.AddTool(server, &mcp.Tool{Name: "get_query", Description: "Get details of a specific Redash query"}, GetQuery)
-	mcp.AddTool(server, &mcp.Tool{Name: "create_query", Description: "Create a new Redash query"}, CreateQuery)
-	mcp.AddTool(server, &mcp.Tool{Name: "execute_query", Description: "Execute a Redash query and return the result"}, ExecuteQuery)
-	mcp.AddTool(server, &mcp.Tool{Name: "update_query", Description: "Update an existing Redash query"}, UpdateQuery)
-	mcp.AddTool(server, &mcp.Tool{Name: "list_data_sources", Description: "List all available Redash data sources"}, ListDataSources)
-	mcp.AddTool(server, &mcp.Tool{Name: "archive_query", Description: "Archive (soft-delete) a Redash query"}, ArchiveQuery)
-
 	if *httpAddr != "" {
-		handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+		baseURL := os.Getenv("REDASH_BASE_URL")
+		if baseURL == "" {
+			log.Fatal("REDASH_BASE_URL is not set")
+		}
+		// fallback is used when a request carries no Authorization header
+		// of its own. If REDASH_API_KEY isn't set, its CredentialProvider
+		// still resolves cleanly to a "no Redash credentials configured"
+		// tool error instead of a nil client, for deployments that rely
+		// solely on per-request credentials.
+		fallback := NewRedashClient(baseURL, APIKeyCredential(os.Getenv("REDASH_API_KEY")))
+
+		handler := mcp.NewStreamableHTTPHandler(func(req *http.Request) *mcp.Server {
+			server := mcp.NewServer(&mcp.Implementation{Name: "greeter"}, nil)
+			registerTools(server, clientForRequest(req, baseURL, fallback))
 			return server
 		}, nil)
 
 		log.Printf("MCP handler listening at %s", *httpAddr)
 		http.ListenAndServe(*httpAddr, handler)
 	} else {
+		client, err := NewRedashClientFromEnv()
+		if err != nil {
+			log.Fatalf("Redash client: %v", err)
+		}
+
+		server := mcp.NewServer(&mcp.Implementation{Name: "greeter"}, nil)
+		registerTools(server, client)
+
 		t := mcp.NewLoggingTransport(mcp.NewStdioTransport(), os.Stderr)
 		if err := server.Run(context.Background(), t); err != nil {
 			log.Printf("Server failed: %v", err)